@@ -1,11 +1,26 @@
 package model
 
+import "time"
+
 // The type of a resource object.
 type SnapshotClass string
 
 type Snapshot struct {
-	SnapshotUUID  string `gorm:"column:UUID; not null; primary_key"`
-	SnapshotName  string `gorm:"column:Name; not null;"`
-	SnapshotClass string `gorm:"column:Class; not null"`
-	pvcName       string `gorm:"column:pvcName; not null"`
+	SnapshotUUID                   string    `gorm:"column:UUID; not null; primary_key"`
+	SnapshotName                   string    `gorm:"column:Name; not null;"`
+	SnapshotClass                  string    `gorm:"column:Class; not null"`
+	pvcName                        string    `gorm:"column:pvcName; not null"`
+	Phase                          string    `gorm:"column:Phase; not null"`
+	ReadyToUse                     bool      `gorm:"column:ReadyToUse; not null"`
+	BoundVolumeSnapshotContentName string    `gorm:"column:BoundVolumeSnapshotContentName"`
+	CreationTime                   time.Time `gorm:"column:CreationTime"`
+	RestoreSize                    string    `gorm:"column:RestoreSize"`
+	FailureReason                  string    `gorm:"column:FailureReason"`
+	ReclaimPolicy                  string    `gorm:"column:ReclaimPolicy; not null"`
+}
+
+type VolumeSnapshotClass struct {
+	Name           string `gorm:"column:Name; not null; primary_key"`
+	Driver         string `gorm:"column:Driver; not null"`
+	DeletionPolicy string `gorm:"column:DeletionPolicy; not null"`
 }
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	snapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1beta1"
+	clientset "github.com/kubernetes-csi/external-snapshotter/client/v6/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+)
+
+type snapshotClient interface {
+	Create(ctx context.Context, namespace string, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error)
+	Get(ctx context.Context, namespace string, name string) (*snapshotv1.VolumeSnapshot, error)
+	Delete(ctx context.Context, namespace string, name string) error
+	Watch(ctx context.Context, namespace string, name string) (watch.Interface, error)
+	ListClasses(ctx context.Context) ([]snapshotv1.VolumeSnapshotClass, error)
+}
+
+func NewSnapshotClient(discoveryClient discovery.DiscoveryInterface, csiClient clientset.Interface) (snapshotClient, error) {
+	if _, err := discoveryClient.ServerResourcesForGroupVersion(snapshotv1.SchemeGroupVersion.String()); err == nil {
+		return &v1SnapshotClient{csiClient: csiClient}, nil
+	}
+	if _, err := discoveryClient.ServerResourcesForGroupVersion(snapshotv1beta1.SchemeGroupVersion.String()); err == nil {
+		return &v1beta1SnapshotClient{csiClient: csiClient}, nil
+	}
+	return nil, fmt.Errorf("cluster serves neither %s nor %s VolumeSnapshot APIs",
+		snapshotv1.SchemeGroupVersion, snapshotv1beta1.SchemeGroupVersion)
+}
+
+func watchFieldSelector(name string) metav1.ListOptions {
+	return metav1.ListOptions{FieldSelector: fmt.Sprintf("metadata.name=%s", name)}
+}
+
+type v1SnapshotClient struct {
+	csiClient clientset.Interface
+}
+
+func (c *v1SnapshotClient) Create(ctx context.Context, namespace string, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
+	return c.csiClient.SnapshotV1().VolumeSnapshots(namespace).Create(ctx, snapshot, metav1.CreateOptions{})
+}
+
+func (c *v1SnapshotClient) Get(ctx context.Context, namespace string, name string) (*snapshotv1.VolumeSnapshot, error) {
+	return c.csiClient.SnapshotV1().VolumeSnapshots(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *v1SnapshotClient) Delete(ctx context.Context, namespace string, name string) error {
+	return c.csiClient.SnapshotV1().VolumeSnapshots(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (c *v1SnapshotClient) Watch(ctx context.Context, namespace string, name string) (watch.Interface, error) {
+	return c.csiClient.SnapshotV1().VolumeSnapshots(namespace).Watch(ctx, watchFieldSelector(name))
+}
+
+func (c *v1SnapshotClient) ListClasses(ctx context.Context) ([]snapshotv1.VolumeSnapshotClass, error) {
+	list, err := c.csiClient.SnapshotV1().VolumeSnapshotClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+type v1beta1SnapshotClient struct {
+	csiClient clientset.Interface
+}
+
+func (c *v1beta1SnapshotClient) Create(ctx context.Context, namespace string, snapshot *snapshotv1.VolumeSnapshot) (*snapshotv1.VolumeSnapshot, error) {
+	created, err := c.csiClient.SnapshotV1beta1().VolumeSnapshots(namespace).Create(ctx, toV1beta1(snapshot), metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromV1beta1(created), nil
+}
+
+func (c *v1beta1SnapshotClient) Get(ctx context.Context, namespace string, name string) (*snapshotv1.VolumeSnapshot, error) {
+	got, err := c.csiClient.SnapshotV1beta1().VolumeSnapshots(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromV1beta1(got), nil
+}
+
+func (c *v1beta1SnapshotClient) Delete(ctx context.Context, namespace string, name string) error {
+	return c.csiClient.SnapshotV1beta1().VolumeSnapshots(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (c *v1beta1SnapshotClient) Watch(ctx context.Context, namespace string, name string) (watch.Interface, error) {
+	watcher, err := c.csiClient.SnapshotV1beta1().VolumeSnapshots(namespace).Watch(ctx, watchFieldSelector(name))
+	if err != nil {
+		return nil, err
+	}
+	return watch.Filter(watcher, func(in watch.Event) (watch.Event, bool) {
+		snapshot, ok := in.Object.(*snapshotv1beta1.VolumeSnapshot)
+		if !ok {
+			return in, true
+		}
+		in.Object = fromV1beta1(snapshot)
+		return in, true
+	}), nil
+}
+
+func (c *v1beta1SnapshotClient) ListClasses(ctx context.Context) ([]snapshotv1.VolumeSnapshotClass, error) {
+	list, err := c.csiClient.SnapshotV1beta1().VolumeSnapshotClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	classes := make([]snapshotv1.VolumeSnapshotClass, 0, len(list.Items))
+	for _, class := range list.Items {
+		classes = append(classes, snapshotv1.VolumeSnapshotClass{
+			ObjectMeta:     class.ObjectMeta,
+			Driver:         class.Driver,
+			DeletionPolicy: snapshotv1.DeletionPolicy(class.DeletionPolicy),
+			Parameters:     class.Parameters,
+		})
+	}
+	return classes, nil
+}
+
+func toV1beta1(snapshot *snapshotv1.VolumeSnapshot) *snapshotv1beta1.VolumeSnapshot {
+	converted := &snapshotv1beta1.VolumeSnapshot{
+		ObjectMeta: snapshot.ObjectMeta,
+		Spec: snapshotv1beta1.VolumeSnapshotSpec{
+			Source: snapshotv1beta1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: snapshot.Spec.Source.PersistentVolumeClaimName,
+				VolumeSnapshotContentName: snapshot.Spec.Source.VolumeSnapshotContentName,
+			},
+			VolumeSnapshotClassName: snapshot.Spec.VolumeSnapshotClassName,
+		},
+	}
+	return converted
+}
+
+func fromV1beta1(snapshot *snapshotv1beta1.VolumeSnapshot) *snapshotv1.VolumeSnapshot {
+	converted := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: snapshot.ObjectMeta,
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source: snapshotv1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: snapshot.Spec.Source.PersistentVolumeClaimName,
+				VolumeSnapshotContentName: snapshot.Spec.Source.VolumeSnapshotContentName,
+			},
+			VolumeSnapshotClassName: snapshot.Spec.VolumeSnapshotClassName,
+		},
+	}
+	if snapshot.Status != nil {
+		converted.Status = &snapshotv1.VolumeSnapshotStatus{
+			BoundVolumeSnapshotContentName: snapshot.Status.BoundVolumeSnapshotContentName,
+			CreationTime:                   snapshot.Status.CreationTime,
+			ReadyToUse:                     snapshot.Status.ReadyToUse,
+			RestoreSize:                    snapshot.Status.RestoreSize,
+		}
+		if snapshot.Status.Error != nil {
+			converted.Status.Error = &snapshotv1.VolumeSnapshotError{
+				Time:    snapshot.Status.Error.Time,
+				Message: snapshot.Status.Error.Message,
+			}
+		}
+	}
+	return converted
+}
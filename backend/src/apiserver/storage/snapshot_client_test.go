@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1"
+	snapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v6/apis/volumesnapshot/v1beta1"
+	fakeclientset "github.com/kubernetes-csi/external-snapshotter/client/v6/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func fakeDiscoveryWithGroupVersions(groupVersions ...string) *discoveryfake.FakeDiscovery {
+	resources := make([]*metav1.APIResourceList, 0, len(groupVersions))
+	for _, gv := range groupVersions {
+		resources = append(resources, &metav1.APIResourceList{GroupVersion: gv})
+	}
+	return &discoveryfake.FakeDiscovery{
+		Fake: &kubetesting.Fake{Resources: resources},
+	}
+}
+
+func TestNewSnapshotClient_PrefersV1(t *testing.T) {
+	discoveryClient := fakeDiscoveryWithGroupVersions(
+		snapshotv1.SchemeGroupVersion.String(),
+		snapshotv1beta1.SchemeGroupVersion.String(),
+	)
+	client, err := NewSnapshotClient(discoveryClient, fakeclientset.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf("NewSnapshotClient returned error: %v", err)
+	}
+	if _, ok := client.(*v1SnapshotClient); !ok {
+		t.Fatalf("expected a v1SnapshotClient when the cluster serves v1, got %T", client)
+	}
+}
+
+func TestNewSnapshotClient_FallsBackToV1beta1(t *testing.T) {
+	discoveryClient := fakeDiscoveryWithGroupVersions(snapshotv1beta1.SchemeGroupVersion.String())
+	client, err := NewSnapshotClient(discoveryClient, fakeclientset.NewSimpleClientset())
+	if err != nil {
+		t.Fatalf("NewSnapshotClient returned error: %v", err)
+	}
+	if _, ok := client.(*v1beta1SnapshotClient); !ok {
+		t.Fatalf("expected a v1beta1SnapshotClient when the cluster only serves v1beta1, got %T", client)
+	}
+}
+
+func TestNewSnapshotClient_ErrorsWhenNeitherServed(t *testing.T) {
+	discoveryClient := fakeDiscoveryWithGroupVersions()
+	if _, err := NewSnapshotClient(discoveryClient, fakeclientset.NewSimpleClientset()); err == nil {
+		t.Fatalf("expected an error when the cluster serves neither VolumeSnapshot API version")
+	}
+}
+
+func TestV1SnapshotClient_CreateGetDelete(t *testing.T) {
+	csiClient := fakeclientset.NewSimpleClientset()
+	client := &v1SnapshotClient{csiClient: csiClient}
+	ctx := context.Background()
+
+	snapshot := &snapshotv1.VolumeSnapshot{ObjectMeta: metav1.ObjectMeta{Name: "snap-1", Namespace: "ns"}}
+	if _, err := client.Create(ctx, "ns", snapshot); err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+
+	got, err := client.Get(ctx, "ns", "snap-1")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if got.Name != "snap-1" {
+		t.Fatalf("expected snapshot named snap-1, got %q", got.Name)
+	}
+
+	if err := client.Delete(ctx, "ns", "snap-1"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if _, err := client.Get(ctx, "ns", "snap-1"); err == nil {
+		t.Fatalf("expected error getting deleted snapshot")
+	}
+}
+
+func TestV1beta1SnapshotClient_CreateGet(t *testing.T) {
+	csiClient := fakeclientset.NewSimpleClientset()
+	client := &v1beta1SnapshotClient{csiClient: csiClient}
+	ctx := context.Background()
+
+	snapshot := &snapshotv1.VolumeSnapshot{ObjectMeta: metav1.ObjectMeta{Name: "snap-1", Namespace: "ns"}}
+	created, err := client.Create(ctx, "ns", snapshot)
+	if err != nil {
+		t.Fatalf("Create returned error: %v", err)
+	}
+	if created.Name != "snap-1" {
+		t.Fatalf("expected snapshot named snap-1, got %q", created.Name)
+	}
+
+	got, err := client.Get(ctx, "ns", "snap-1")
+	if err != nil {
+		t.Fatalf("Get returned error: %v", err)
+	}
+	if got.Name != "snap-1" {
+		t.Fatalf("expected snapshot named snap-1, got %q", got.Name)
+	}
+}
+
+func TestToFromV1beta1_RoundTrip(t *testing.T) {
+	pvcName := "pvc-1"
+	className := "my-class"
+	v1Snapshot := &snapshotv1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "snap-1", Namespace: "ns"},
+		Spec: snapshotv1.VolumeSnapshotSpec{
+			Source:                  snapshotv1.VolumeSnapshotSource{PersistentVolumeClaimName: &pvcName},
+			VolumeSnapshotClassName: &className,
+		},
+	}
+
+	converted := toV1beta1(v1Snapshot)
+	if *converted.Spec.Source.PersistentVolumeClaimName != pvcName {
+		t.Fatalf("expected PVC name %q to round-trip, got %q", pvcName, *converted.Spec.Source.PersistentVolumeClaimName)
+	}
+	if *converted.Spec.VolumeSnapshotClassName != className {
+		t.Fatalf("expected class name %q to round-trip, got %q", className, *converted.Spec.VolumeSnapshotClassName)
+	}
+
+	readyToUse := true
+	converted.Status = &snapshotv1beta1.VolumeSnapshotStatus{ReadyToUse: &readyToUse}
+	back := fromV1beta1(converted)
+	if back.Status == nil || back.Status.ReadyToUse == nil || !*back.Status.ReadyToUse {
+		t.Fatalf("expected ReadyToUse to round-trip through fromV1beta1, got %+v", back.Status)
+	}
+}
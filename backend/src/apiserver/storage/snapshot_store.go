@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/kubeflow/pipelines/backend/src/common/util"
@@ -12,24 +14,185 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 )
 
+type SnapshotPhase string
+
+const (
+	SnapshotPhasePending  SnapshotPhase = "Pending"
+	SnapshotPhaseReady    SnapshotPhase = "Ready"
+	SnapshotPhaseFailed   SnapshotPhase = "Failed"
+	SnapshotPhaseDeleting SnapshotPhase = "Deleting"
+)
+
+type ReclaimPolicy string
+
+const (
+	ReclaimPolicyDelete ReclaimPolicy = "Delete"
+	ReclaimPolicyRetain ReclaimPolicy = "Retain"
+)
+
 type SnapshotStoreInterface interface {
 	// Create a run entry in the database
 	CreatePVC(pvcName string, size string, namespace string) error
-	createSnapshot(pvcName string, snapshotName string, namespace string) error
+	createSnapshot(pvcName string, snapshotName string, namespace string, snapshotClass string) error
+	RestorePVCFromSnapshot(snapshotName, newPvcName, namespace, storageClass, restoreSize string, accessModes []v1.PersistentVolumeAccessMode, volumeMode string, labels map[string]string) (string, error)
+	ListVolumeSnapshotClasses(ctx context.Context) ([]snapshotv1.VolumeSnapshotClass, error)
+	WaitForSnapshotReady(ctx context.Context, name string, namespace string, timeout time.Duration) (*snapshotv1.VolumeSnapshot, error)
+	DeletePVC(name string, namespace string, cascade bool) error
+	DeleteSnapshot(name string, namespace string) error
+	CreatePVCFromTemplate(template PVCTemplate, runID string, stepID string) (string, error)
 }
 
 type SnapshotStore struct {
-	db        *DB
-	csiClient clientset.Interface
-	uuid      util.UUIDGeneratorInterface
-	client    *kubernetes.Clientset
+	db          *DB
+	csiClient   clientset.Interface
+	snapshotAPI snapshotClient
+	uuid        util.UUIDGeneratorInterface
+	client      *kubernetes.Clientset
+}
+
+func (s *SnapshotStore) snapshots() snapshotClient {
+	if s.snapshotAPI != nil {
+		return s.snapshotAPI
+	}
+	return &v1SnapshotClient{csiClient: s.csiClient}
+}
+
+func NewSnapshotStore(db *DB, csiClient clientset.Interface, client *kubernetes.Clientset, discoveryClient discovery.DiscoveryInterface, uuid util.UUIDGeneratorInterface) (*SnapshotStore, error) {
+	snapshotAPI, err := NewSnapshotClient(discoveryClient, csiClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to negotiate VolumeSnapshot API version: %v", err)
+	}
+	return &SnapshotStore{
+		db:          db,
+		csiClient:   csiClient,
+		snapshotAPI: snapshotAPI,
+		uuid:        uuid,
+		client:      client,
+	}, nil
+}
+
+var SnapshotStoreColumn = []string{"UUID", "CreatedAt", "Class", "Name", "Namespace", "pvcName", "ContentName",
+	"Phase", "ReadyToUse", "BoundVolumeSnapshotContentName", "CreationTime", "RestoreSize", "FailureReason", "ReclaimPolicy"}
+var PvcColumn = []string{"UUID", "CreatedAt", "AccessMode", "Class", "Name", "Namespace", "ReclaimPolicy", "OwnerRunID"}
+
+type PVCTemplate struct {
+	NamePrefix   string
+	Namespace    string
+	StorageClass string
+	Size         string
+	AccessModes  []v1.PersistentVolumeAccessMode
+	VolumeMode   string
+	Labels       map[string]string
 }
 
-var SnapshotStoreColumn = []string{"UUID", "CreatedAt", "Class", "Name", "Namespace", "pvcName", "ContentName"}
-var PvcColumn = []string{"UUID", "CreatedAt", "AccessMode", "Class", "Name", "Namespace"}
+var SnapshotClassColumn = []string{"Name", "Driver", "DeletionPolicy"}
+
+func (s *SnapshotStore) ListVolumeSnapshotClasses(ctx context.Context) ([]snapshotv1.VolumeSnapshotClass, error) {
+	classes, err := s.snapshots().ListClasses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volume snapshot classes: %v", err)
+	}
+	return classes, nil
+}
+
+func (s *SnapshotStore) resolveSnapshotClass(ctx context.Context, pvcName string, namespace string) (string, error) {
+	pvc, err := s.client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up PVC %q to resolve its snapshot class: %v", pvcName, err)
+	}
+	if pvc.Spec.StorageClassName == nil {
+		return "", fmt.Errorf("PVC %q has no StorageClassName, cannot resolve a snapshot class", pvcName)
+	}
+	storageClass, err := s.client.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up StorageClass %q: %v", *pvc.Spec.StorageClassName, err)
+	}
+
+	classes, err := s.ListVolumeSnapshotClasses(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, class := range classes {
+		if class.Driver == storageClass.Provisioner {
+			if err := s.persistSnapshotClass(class); err != nil {
+				return "", err
+			}
+			return class.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no VolumeSnapshotClass found for driver %q", storageClass.Provisioner)
+}
+
+func (s *SnapshotStore) ensureSnapshotClassPersisted(ctx context.Context, className string) error {
+	row := s.db.QueryRow(`SELECT Name FROM snapshot_classes WHERE Name = ?`, className)
+	var existingName string
+	if err := row.Scan(&existingName); err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return util.NewInternalServerError(err, "Failed to look up existing snapshot class %q: %v", className, err.Error())
+	}
+
+	classes, err := s.ListVolumeSnapshotClasses(ctx)
+	if err != nil {
+		return err
+	}
+	for _, class := range classes {
+		if class.Name == className {
+			return s.insertSnapshotClass(class)
+		}
+	}
+	return fmt.Errorf("VolumeSnapshotClass %q not found", className)
+}
+
+func (s *SnapshotStore) persistSnapshotClass(class snapshotv1.VolumeSnapshotClass) error {
+	row := s.db.QueryRow(`SELECT Name FROM snapshot_classes WHERE Name = ?`, class.Name)
+	var existingName string
+	if err := row.Scan(&existingName); err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return util.NewInternalServerError(err, "Failed to look up existing snapshot class %q: %v", class.Name, err.Error())
+	}
+	return s.insertSnapshotClass(class)
+}
+
+func (s *SnapshotStore) insertSnapshotClass(class snapshotv1.VolumeSnapshotClass) error {
+	sql, args, err := sq.
+		Insert("snapshot_classes").
+		SetMap(
+			sq.Eq{
+				"Name":           class.Name,
+				"Driver":         class.Driver,
+				"DeletionPolicy": class.DeletionPolicy}).
+		ToSql()
+	if err != nil {
+		return util.NewInternalServerError(err, "Failed to create query to insert snapshot class to snapshot_classes table: %v",
+			err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to start a transaction to create a new snapshot class: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sql, args...)
+	if err != nil {
+		tx.Rollback()
+		return util.NewInternalServerError(err,
+			"Failed to add snapshot class to snapshot_classes table: %v",
+			err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to update snapshot class in a
+			transaction: %v`, err.Error())
+	}
+	return nil
+}
 
 func (s *SnapshotStore) CreatePVC(pvcName string, size string, namespace string) (string, error) {
 	// Create a PVC
@@ -64,12 +227,13 @@ func (s *SnapshotStore) CreatePVC(pvcName string, size string, namespace string)
 		Insert("pvcs").
 		SetMap(
 			sq.Eq{
-				"UUID":       pvc.UID,
-				"CreatedAt":  pvc.CreationTimestamp,
-				"AccessMode": pvc.Spec.AccessModes,
-				"Class":      pvc.Spec.StorageClassName,
-				"Name":       pvc.Name,
-				"Namespace":  pvc.Namespace}).
+				"UUID":          pvc.UID,
+				"CreatedAt":     pvc.CreationTimestamp,
+				"AccessMode":    pvc.Spec.AccessModes,
+				"Class":         pvc.Spec.StorageClassName,
+				"Name":          pvc.Name,
+				"Namespace":     pvc.Namespace,
+				"ReclaimPolicy": ReclaimPolicyDelete}).
 		ToSql()
 	if err != nil {
 		return "", util.NewInternalServerError(err, "Failed to create query to insert pvc to pvc table: %v",
@@ -96,9 +260,101 @@ func (s *SnapshotStore) CreatePVC(pvcName string, size string, namespace string)
 	return pvcName, nil
 }
 
-func (s *SnapshotStore) createSnapshot(pvcName string, snapshotName string, namespace string) error {
-	// Create a new VolumeSnapshot object
-	SnapshotClass := "longhorn"
+func (s *SnapshotStore) RestorePVCFromSnapshot(snapshotName, newPvcName, namespace, storageClass, restoreSize string, accessModes []v1.PersistentVolumeAccessMode, volumeMode string, labels map[string]string) (string, error) {
+	row := s.db.QueryRow(
+		`SELECT Name FROM snapshots WHERE Name = ? AND Namespace = ?`,
+		snapshotName, namespace)
+	var existingName string
+	if err := row.Scan(&existingName); err != nil {
+		if err == sql.ErrNoRows {
+			return "", util.NewInvalidInputError("Snapshot %q not found in namespace %q", snapshotName, namespace)
+		}
+		return "", util.NewInternalServerError(err, "Failed to look up snapshot %q: %v", snapshotName, err.Error())
+	}
+
+	apiGroup := "snapshot.storage.k8s.io"
+	pvcVolumeMode := v1.PersistentVolumeMode(volumeMode)
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   newPvcName,
+			Labels: labels,
+			Annotations: map[string]string{
+				"kubeflow.org/pvc-name": newPvcName,
+			},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: &storageClass,
+			VolumeMode:       &pvcVolumeMode,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: resource.MustParse(restoreSize),
+				},
+			},
+			DataSource: &v1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     "VolumeSnapshot",
+				Name:     snapshotName,
+			},
+		},
+	}
+
+	_, err := s.client.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("PVC %q already exists", newPvcName)
+		} else {
+			return "", fmt.Errorf("Failed to create PVC %q: %v", newPvcName, err)
+		}
+	}
+	sql, args, err := sq.
+		Insert("pvcs").
+		SetMap(
+			sq.Eq{
+				"UUID":          pvc.UID,
+				"CreatedAt":     pvc.CreationTimestamp,
+				"AccessMode":    pvc.Spec.AccessModes,
+				"Class":         pvc.Spec.StorageClassName,
+				"Name":          pvc.Name,
+				"Namespace":     pvc.Namespace,
+				"ReclaimPolicy": ReclaimPolicyDelete}).
+		ToSql()
+	if err != nil {
+		return "", util.NewInternalServerError(err, "Failed to create query to insert pvc to pvc table: %v",
+			err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", util.NewInternalServerError(err,
+			`Failed to start a transaction to create a new pvc: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sql, args...)
+	if err != nil {
+		tx.Rollback()
+		return "", util.NewInternalServerError(err,
+			"Failed to add pvc to pvc table: %v",
+			err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return "", util.NewInternalServerError(err,
+			`Failed to update pvc and pvc in a
+			transaction: %v`, err.Error())
+	}
+	return newPvcName, nil
+}
+
+func (s *SnapshotStore) createSnapshot(pvcName string, snapshotName string, namespace string, snapshotClass string) error {
+	ctx := context.TODO()
+	if snapshotClass == "" {
+		resolved, err := s.resolveSnapshotClass(ctx, pvcName, namespace)
+		if err != nil {
+			return fmt.Errorf("failed to resolve snapshot class: %v", err)
+		}
+		snapshotClass = resolved
+	} else if err := s.ensureSnapshotClassPersisted(ctx, snapshotClass); err != nil {
+		return fmt.Errorf("failed to persist snapshot class: %v", err)
+	}
 
 	snapshot := &snapshotv1.VolumeSnapshot{
 
@@ -110,11 +366,11 @@ func (s *SnapshotStore) createSnapshot(pvcName string, snapshotName string, name
 			Source: snapshotv1.VolumeSnapshotSource{
 				PersistentVolumeClaimName: &pvcName,
 			},
-			VolumeSnapshotClassName: &SnapshotClass,
+			VolumeSnapshotClassName: &snapshotClass,
 		},
 	}
-	// Use the CSI client to create the snapshot
-	_, err := s.csiClient.SnapshotV1().VolumeSnapshots(snapshot.Namespace).Create(context.TODO(), snapshot, metav1.CreateOptions{})
+	// Use the version-negotiated snapshot client to create the snapshot
+	_, err := s.snapshots().Create(ctx, snapshot.Namespace, snapshot)
 	if err != nil {
 		return fmt.Errorf("failed to create snapshot: %v", err)
 	}
@@ -123,13 +379,16 @@ func (s *SnapshotStore) createSnapshot(pvcName string, snapshotName string, name
 		Insert("snapshots").
 		SetMap(
 			sq.Eq{
-				"UUID":        snapshot.UID,
-				"CreatedAt":   snapshot.CreationTimestamp,
-				"Class":       snapshot.Spec.VolumeSnapshotClassName,
-				"Name":        snapshot.Name,
-				"Namespace":   snapshot.Namespace,
-				"pvcName":     snapshot.Spec.Source.PersistentVolumeClaimName,
-				"ContentName": snapshot.Spec.Source.VolumeSnapshotContentName}).
+				"UUID":          snapshot.UID,
+				"CreatedAt":     snapshot.CreationTimestamp,
+				"Class":         snapshot.Spec.VolumeSnapshotClassName,
+				"Name":          snapshot.Name,
+				"Namespace":     snapshot.Namespace,
+				"pvcName":       snapshot.Spec.Source.PersistentVolumeClaimName,
+				"ContentName":   snapshot.Spec.Source.VolumeSnapshotContentName,
+				"Phase":         SnapshotPhasePending,
+				"ReadyToUse":    false,
+				"ReclaimPolicy": ReclaimPolicyDelete}).
 		ToSql()
 	if err != nil {
 		return util.NewInternalServerError(err, "Failed to create query to insert snapshot to snapshot table: %v",
@@ -155,3 +414,375 @@ func (s *SnapshotStore) createSnapshot(pvcName string, snapshotName string, name
 	}
 	return nil
 }
+
+func (s *SnapshotStore) WaitForSnapshotReady(ctx context.Context, name string, namespace string, timeout time.Duration) (*snapshotv1.VolumeSnapshot, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	watcher, err := s.snapshots().Watch(ctx, namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch snapshot %q: %v", name, err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("watch for snapshot %q closed before it became ready", name)
+			}
+			snapshot, ok := event.Object.(*snapshotv1.VolumeSnapshot)
+			if !ok || snapshot.Status == nil {
+				continue
+			}
+			if snapshot.Status.Error != nil {
+				failureReason := ""
+				if snapshot.Status.Error.Message != nil {
+					failureReason = *snapshot.Status.Error.Message
+				}
+				if err := s.updateSnapshotStatus(name, namespace, SnapshotPhaseFailed, snapshot, failureReason); err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("snapshot %q failed: %s", name, failureReason)
+			}
+			if snapshot.Status.ReadyToUse != nil && *snapshot.Status.ReadyToUse {
+				if err := s.updateSnapshotStatus(name, namespace, SnapshotPhaseReady, snapshot, ""); err != nil {
+					return nil, err
+				}
+				return snapshot, nil
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for snapshot %q to become ready: %v", name, ctx.Err())
+		}
+	}
+}
+
+func (s *SnapshotStore) updateSnapshotStatus(name string, namespace string, phase SnapshotPhase, snapshot *snapshotv1.VolumeSnapshot, failureReason string) error {
+	values := sq.Eq{
+		"Phase":         phase,
+		"FailureReason": failureReason,
+	}
+	if snapshot.Status.ReadyToUse != nil {
+		values["ReadyToUse"] = *snapshot.Status.ReadyToUse
+	}
+	if snapshot.Status.BoundVolumeSnapshotContentName != nil {
+		values["BoundVolumeSnapshotContentName"] = *snapshot.Status.BoundVolumeSnapshotContentName
+	}
+	if snapshot.Status.CreationTime != nil {
+		values["CreationTime"] = snapshot.Status.CreationTime.Time
+	}
+	if snapshot.Status.RestoreSize != nil {
+		values["RestoreSize"] = snapshot.Status.RestoreSize.String()
+	}
+
+	sql, args, err := sq.
+		Update("snapshots").
+		SetMap(values).
+		Where(sq.Eq{"Name": name, "Namespace": namespace}).
+		ToSql()
+	if err != nil {
+		return util.NewInternalServerError(err, "Failed to create query to update snapshot %q: %v", name, err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to start a transaction to update snapshot status: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sql, args...)
+	if err != nil {
+		tx.Rollback()
+		return util.NewInternalServerError(err,
+			"Failed to update snapshot %q status: %v", name, err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to commit snapshot status update in a
+			transaction: %v`, err.Error())
+	}
+	return nil
+}
+
+func (s *SnapshotStore) pvcReclaimPolicy(name string, namespace string) (ReclaimPolicy, error) {
+	row := s.db.QueryRow(
+		`SELECT ReclaimPolicy FROM pvcs WHERE Name = ? AND Namespace = ?`,
+		name, namespace)
+	var policy string
+	if err := row.Scan(&policy); err != nil {
+		if err == sql.ErrNoRows {
+			return "", util.NewInvalidInputError("PVC %q not found in namespace %q", name, namespace)
+		}
+		return "", util.NewInternalServerError(err, "Failed to look up reclaim policy for PVC %q: %v", name, err.Error())
+	}
+	return ReclaimPolicy(policy), nil
+}
+
+func (s *SnapshotStore) snapshotReclaimPolicy(name string, namespace string) (ReclaimPolicy, error) {
+	row := s.db.QueryRow(
+		`SELECT ReclaimPolicy FROM snapshots WHERE Name = ? AND Namespace = ?`,
+		name, namespace)
+	var policy string
+	if err := row.Scan(&policy); err != nil {
+		if err == sql.ErrNoRows {
+			return "", util.NewInvalidInputError("Snapshot %q not found in namespace %q", name, namespace)
+		}
+		return "", util.NewInternalServerError(err, "Failed to look up reclaim policy for snapshot %q: %v", name, err.Error())
+	}
+	return ReclaimPolicy(policy), nil
+}
+
+func (s *SnapshotStore) DeletePVC(name string, namespace string, cascade bool) error {
+	policy, err := s.pvcReclaimPolicy(name, namespace)
+	if err != nil {
+		return err
+	}
+	if policy != ReclaimPolicyRetain {
+		propagation := metav1.DeletePropagationOrphan
+		if cascade {
+			propagation = metav1.DeletePropagationForeground
+		}
+		err := s.client.CoreV1().PersistentVolumeClaims(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{
+			PropagationPolicy: &propagation,
+		})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PVC %q: %v", name, err)
+		}
+		if err == nil {
+			if err := s.waitForPVCDeleted(context.TODO(), name, namespace); err != nil {
+				return err
+			}
+		}
+	}
+
+	sqlStr, args, err := sq.Delete("pvcs").Where(sq.Eq{"Name": name, "Namespace": namespace}).ToSql()
+	if err != nil {
+		return util.NewInternalServerError(err, "Failed to create query to delete pvc %q: %v", name, err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to start a transaction to delete a pvc: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sqlStr, args...)
+	if err != nil {
+		tx.Rollback()
+		return util.NewInternalServerError(err,
+			"Failed to delete pvc %q from pvc table: %v", name, err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to commit pvc deletion in a
+			transaction: %v`, err.Error())
+	}
+	return nil
+}
+
+func (s *SnapshotStore) waitForPVCDeleted(ctx context.Context, name string, namespace string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	watcher, err := s.client.CoreV1().PersistentVolumeClaims(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%s", name),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to watch PVC %q for deletion: %v", name, err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok || event.Type == watch.Deleted {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for PVC %q to be deleted: %v", name, ctx.Err())
+		}
+	}
+}
+
+func (s *SnapshotStore) setSnapshotPhase(name string, namespace string, phase SnapshotPhase) error {
+	sqlStr, args, err := sq.
+		Update("snapshots").
+		SetMap(sq.Eq{"Phase": phase}).
+		Where(sq.Eq{"Name": name, "Namespace": namespace}).
+		ToSql()
+	if err != nil {
+		return util.NewInternalServerError(err, "Failed to create query to update snapshot %q phase: %v", name, err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to start a transaction to update snapshot phase: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sqlStr, args...)
+	if err != nil {
+		tx.Rollback()
+		return util.NewInternalServerError(err,
+			"Failed to update snapshot %q phase: %v", name, err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to commit snapshot phase update in a
+			transaction: %v`, err.Error())
+	}
+	return nil
+}
+
+func (s *SnapshotStore) DeleteSnapshot(name string, namespace string) error {
+	policy, err := s.snapshotReclaimPolicy(name, namespace)
+	if err != nil {
+		return err
+	}
+	if policy != ReclaimPolicyRetain {
+		if err := s.setSnapshotPhase(name, namespace, SnapshotPhaseDeleting); err != nil {
+			return err
+		}
+		err := s.snapshots().Delete(context.TODO(), namespace, name)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete snapshot %q: %v", name, err)
+		}
+		if err == nil {
+			if err := s.waitForSnapshotDeleted(context.TODO(), name, namespace); err != nil {
+				return err
+			}
+		}
+	}
+
+	sqlStr, args, err := sq.Delete("snapshots").Where(sq.Eq{"Name": name, "Namespace": namespace}).ToSql()
+	if err != nil {
+		return util.NewInternalServerError(err, "Failed to create query to delete snapshot %q: %v", name, err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to start a transaction to delete a snapshot: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sqlStr, args...)
+	if err != nil {
+		tx.Rollback()
+		return util.NewInternalServerError(err,
+			"Failed to delete snapshot %q from snapshot table: %v", name, err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return util.NewInternalServerError(err,
+			`Failed to commit snapshot deletion in a
+			transaction: %v`, err.Error())
+	}
+	return nil
+}
+
+func (s *SnapshotStore) waitForSnapshotDeleted(ctx context.Context, name string, namespace string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	watcher, err := s.snapshots().Watch(ctx, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to watch snapshot %q for deletion: %v", name, err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.ResultChan():
+			if !ok || event.Type == watch.Deleted {
+				return nil
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for snapshot %q to be deleted: %v", name, ctx.Err())
+		}
+	}
+}
+
+func pvcTemplateLookupQuery(template PVCTemplate, runID string, pvcName string) (string, []interface{}, error) {
+	return sq.
+		Select("Name").
+		From("pvcs").
+		Where(sq.Eq{"OwnerRunID": runID, "Namespace": template.Namespace, "Name": pvcName}).
+		ToSql()
+}
+
+func (s *SnapshotStore) CreatePVCFromTemplate(template PVCTemplate, runID string, stepID string) (string, error) {
+	pvcName := fmt.Sprintf("%s-%s-%s", template.NamePrefix, runID, stepID)
+	lookupSQL, lookupArgs, err := pvcTemplateLookupQuery(template, runID, pvcName)
+	if err != nil {
+		return "", util.NewInternalServerError(err, "Failed to create query to look up existing PVC for run %q step %q: %v", runID, stepID, err.Error())
+	}
+	row := s.db.QueryRow(lookupSQL, lookupArgs...)
+	var existingName string
+	if err := row.Scan(&existingName); err == nil {
+		return existingName, nil
+	} else if err != sql.ErrNoRows {
+		return "", util.NewInternalServerError(err, "Failed to look up existing PVC for run %q step %q: %v", runID, stepID, err.Error())
+	}
+
+	pvcVolumeMode := v1.PersistentVolumeMode(template.VolumeMode)
+	storageClass := template.StorageClass
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   pvcName,
+			Labels: template.Labels,
+			Annotations: map[string]string{
+				"kubeflow.org/pvc-name": pvcName,
+			},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      template.AccessModes,
+			StorageClassName: &storageClass,
+			VolumeMode:       &pvcVolumeMode,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceStorage: resource.MustParse(template.Size),
+				},
+			},
+		},
+	}
+
+	_, err := s.client.CoreV1().PersistentVolumeClaims(template.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+	if err != nil {
+		if errors.IsAlreadyExists(err) {
+			return "", fmt.Errorf("PVC %q already exists", pvcName)
+		}
+		return "", fmt.Errorf("Failed to create PVC %q: %v", pvcName, err)
+	}
+
+	sqlStr, args, err := sq.
+		Insert("pvcs").
+		SetMap(
+			sq.Eq{
+				"UUID":          pvc.UID,
+				"CreatedAt":     pvc.CreationTimestamp,
+				"AccessMode":    pvc.Spec.AccessModes,
+				"Class":         pvc.Spec.StorageClassName,
+				"Name":          pvc.Name,
+				"Namespace":     pvc.Namespace,
+				"ReclaimPolicy": ReclaimPolicyDelete,
+				"OwnerRunID":    runID}).
+		ToSql()
+	if err != nil {
+		return "", util.NewInternalServerError(err, "Failed to create query to insert pvc to pvc table: %v",
+			err.Error())
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", util.NewInternalServerError(err,
+			`Failed to start a transaction to create a new pvc: %v`,
+			err.Error())
+	}
+	_, err = tx.Exec(sqlStr, args...)
+	if err != nil {
+		tx.Rollback()
+		return "", util.NewInternalServerError(err,
+			"Failed to add pvc to pvc table: %v",
+			err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return "", util.NewInternalServerError(err,
+			`Failed to update pvc and pvc in a
+			transaction: %v`, err.Error())
+	}
+	return pvcName, nil
+}
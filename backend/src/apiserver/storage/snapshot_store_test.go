@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPvcTemplateLookupQuery_FiltersByNamespace(t *testing.T) {
+	template := PVCTemplate{
+		NamePrefix: "data",
+		Namespace:  "kubeflow-user",
+	}
+	sql, args, err := pvcTemplateLookupQuery(template, "run-1", "data-run-1-step-1")
+	if err != nil {
+		t.Fatalf("pvcTemplateLookupQuery returned error: %v", err)
+	}
+	if !strings.Contains(sql, "Namespace = ?") {
+		t.Fatalf("expected query to filter by Namespace, got: %s", sql)
+	}
+
+	var foundNamespace bool
+	for _, arg := range args {
+		if arg == template.Namespace {
+			foundNamespace = true
+		}
+	}
+	if !foundNamespace {
+		t.Fatalf("expected args %v to contain namespace %q", args, template.Namespace)
+	}
+}
+
+func TestPvcTemplateLookupQuery_DifferentNamespacesProduceDifferentArgs(t *testing.T) {
+	templateA := PVCTemplate{NamePrefix: "data", Namespace: "team-a"}
+	templateB := PVCTemplate{NamePrefix: "data", Namespace: "team-b"}
+
+	_, argsA, err := pvcTemplateLookupQuery(templateA, "run-1", "data-run-1-step-1")
+	if err != nil {
+		t.Fatalf("pvcTemplateLookupQuery returned error: %v", err)
+	}
+	_, argsB, err := pvcTemplateLookupQuery(templateB, "run-1", "data-run-1-step-1")
+	if err != nil {
+		t.Fatalf("pvcTemplateLookupQuery returned error: %v", err)
+	}
+
+	hasArg := func(args []interface{}, want string) bool {
+		for _, arg := range args {
+			if arg == want {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasArg(argsA, "team-a") || hasArg(argsA, "team-b") {
+		t.Fatalf("expected args %v to contain team-a only", argsA)
+	}
+	if !hasArg(argsB, "team-b") || hasArg(argsB, "team-a") {
+		t.Fatalf("expected args %v to contain team-b only", argsB)
+	}
+}
+
+func TestPvcTemplateLookupQuery_MatchesNameExactly(t *testing.T) {
+	template := PVCTemplate{NamePrefix: "data", Namespace: "ns"}
+	sql, args, err := pvcTemplateLookupQuery(template, "run-1", "data-run-1-step-1")
+	if err != nil {
+		t.Fatalf("pvcTemplateLookupQuery returned error: %v", err)
+	}
+	if strings.Contains(sql, "LIKE") {
+		t.Fatalf("expected an exact Name match, got a LIKE query: %s", sql)
+	}
+	if !strings.Contains(sql, "Name = ?") {
+		t.Fatalf("expected query to match Name exactly, got: %s", sql)
+	}
+
+	var foundName bool
+	for _, arg := range args {
+		if arg == "data-run-1-step-1" {
+			foundName = true
+		}
+	}
+	if !foundName {
+		t.Fatalf("expected args %v to contain the exact PVC name", args)
+	}
+}